@@ -0,0 +1,319 @@
+package systemd_units
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// fakeDbusConn is a minimal fake of dbusConn for exercising gatherDbus
+// without a real system bus.
+type fakeDbusConn struct {
+	units     []dbus.UnitStatus
+	unitFiles []dbus.UnitFile
+	unitProps map[string]map[string]interface{}
+	closed    bool
+}
+
+func (f *fakeDbusConn) ListUnitsContext(ctx context.Context) ([]dbus.UnitStatus, error) {
+	return f.units, nil
+}
+
+func (f *fakeDbusConn) ListUnitFilesContext(ctx context.Context) ([]dbus.UnitFile, error) {
+	return f.unitFiles, nil
+}
+
+// GetUnitTypePropertiesContext mirrors the real go-systemd contract: callers
+// pass the short interface name (e.g. "Service", "Timer"), not the
+// fully-qualified "org.freedesktop.systemd1.Service". Only return properties
+// when the caller got that right, so a regression back to passing a
+// fully-qualified name makes these tests fail instead of silently passing.
+func (f *fakeDbusConn) GetUnitTypePropertiesContext(ctx context.Context, unit string, unitType string) (map[string]interface{}, error) {
+	if want, ok := unitInterfaces[unitExt(unit)]; !ok || unitType != want {
+		return nil, nil
+	}
+	return f.unitProps[unit], nil
+}
+
+func (f *fakeDbusConn) Close() {
+	f.closed = true
+}
+
+func TestGatherDbus(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{
+				Name:        "telegraf.service",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "running",
+			},
+			{
+				Name:        "telegraf.timer",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "waiting",
+			},
+		},
+		unitFiles: []dbus.UnitFile{
+			{Path: "/usr/lib/systemd/system/telegraf.service", Type: "enabled"},
+		},
+	}
+
+	s := &SystemdUnits{
+		Timeout:   internal.Duration{Duration: time.Second},
+		UnitTypes: []string{"service"},
+		Mode:      "dbus",
+		Scope:     "system",
+		conn:      conn,
+		unitTypes: map[string]bool{"service": true},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{
+			"load_code":   0,
+			"active_code": 0,
+			"sub_code":    0,
+			"state_code":  1,
+		},
+		map[string]string{
+			"name":  "telegraf.service",
+			"state": "enabled",
+			"load":  "loaded",
+			"sub":   "running",
+			"scope": "system",
+		},
+	)
+}
+
+func TestGatherDbusUnknownState(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{
+				Name:        "telegraf.service",
+				LoadState:   "bogus",
+				ActiveState: "active",
+				SubState:    "running",
+			},
+		},
+	}
+
+	s := &SystemdUnits{
+		Timeout:   internal.Duration{Duration: time.Second},
+		UnitTypes: []string{"service"},
+		Mode:      "dbus",
+		conn:      conn,
+		unitTypes: map[string]bool{"service": true},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestGatherDbusIncludeExclude(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+			{Name: "postgres-main.service", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+			{Name: "telegraf.service", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+		},
+	}
+
+	s := &SystemdUnits{
+		Timeout:   internal.Duration{Duration: time.Second},
+		UnitTypes: []string{"service"},
+		Mode:      "dbus",
+		conn:      conn,
+		unitTypes: map[string]bool{"service": true},
+	}
+	include, err := compileGlobs([]string{"nginx.service", "postgres*.service"})
+	require.NoError(t, err)
+	s.includeGlobs = include
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	seen := make(map[string]bool)
+	for _, m := range acc.Metrics {
+		seen[m.Tags["name"]] = true
+	}
+	require.True(t, seen["nginx.service"])
+	require.True(t, seen["postgres-main.service"])
+	require.False(t, seen["telegraf.service"])
+}
+
+func TestGatherDbusTimerMetrics(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{
+				Name:        "telegraf.timer",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "waiting",
+			},
+		},
+		unitProps: map[string]map[string]interface{}{
+			"telegraf.timer": {
+				"NextElapseUSecRealtime": uint64(0),
+				"LastTriggerUSec":        uint64(1000),
+			},
+		},
+	}
+
+	collect := true
+	s := &SystemdUnits{
+		Timeout:             internal.Duration{Duration: time.Second},
+		UnitTypes:           []string{"timer"},
+		Mode:                "dbus",
+		CollectTimerMetrics: &collect,
+		conn:                conn,
+		unitTypes:           map[string]bool{"timer": true},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	require.Len(t, acc.Metrics, 1)
+	fields := acc.Metrics[0].Fields
+	require.Equal(t, uint64(1000), fields["last_trigger_usec"])
+	require.NotContains(t, fields, "next_elapse_usec_realtime")
+	require.NotContains(t, fields, "seconds_until_next_trigger")
+}
+
+func TestGatherDbusMountMetrics(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{
+				Name:        "data.mount",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "mounted",
+			},
+		},
+		unitProps: map[string]map[string]interface{}{
+			"data.mount": {
+				"Where":   "/data",
+				"What":    "/dev/sdb1",
+				"Options": "rw,relatime",
+			},
+		},
+	}
+
+	collect := true
+	s := &SystemdUnits{
+		Timeout:             internal.Duration{Duration: time.Second},
+		UnitTypes:           []string{"mount"},
+		Mode:                "dbus",
+		CollectMountMetrics: &collect,
+		conn:                conn,
+		unitTypes:           map[string]bool{"mount": true},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	require.Len(t, acc.Metrics, 1)
+	m := acc.Metrics[0]
+	require.Equal(t, "/data", m.Tags["where"])
+	require.Equal(t, "/dev/sdb1", m.Tags["what"])
+	require.Equal(t, true, m.Fields["mounted"])
+}
+
+func TestGatherDbusScopeTags(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{Name: "telegraf.service", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+		},
+	}
+
+	s := &SystemdUnits{
+		Timeout:   internal.Duration{Duration: time.Second},
+		UnitTypes: []string{"service"},
+		Mode:      "dbus",
+		Scope:     "user",
+		Machine:   "webapp",
+		conn:      conn,
+		unitTypes: map[string]bool{"service": true},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "user", acc.Metrics[0].Tags["scope"])
+	require.Equal(t, "webapp", acc.Metrics[0].Tags["machine"])
+}
+
+func TestResolveUID(t *testing.T) {
+	s := &SystemdUnits{UID: 1000}
+	uid, err := s.resolveUID()
+	require.NoError(t, err)
+	require.Equal(t, 1000, uid)
+}
+
+func TestNewUserBusConnUnreadableSocket(t *testing.T) {
+	_, err := newUserBusConn(999999)
+	require.Error(t, err)
+}
+
+func TestGatherDbusResourceAccounting(t *testing.T) {
+	conn := &fakeDbusConn{
+		units: []dbus.UnitStatus{
+			{
+				Name:        "telegraf.service",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "running",
+			},
+		},
+		unitProps: map[string]map[string]interface{}{
+			"telegraf.service": {
+				"CPUUsageNSec":  uint64(123456),
+				"MemoryCurrent": uint64(4096),
+				"TasksCurrent":  uint64Max,
+			},
+		},
+	}
+
+	s := &SystemdUnits{
+		Timeout:               internal.Duration{Duration: time.Second},
+		UnitTypes:             []string{"service"},
+		Mode:                  "dbus",
+		CollectUnitProperties: true,
+		ResourceAccounting:    true,
+		Scope:                 "system",
+		conn:                  conn,
+		unitTypes:             map[string]bool{"service": true},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, measurement,
+		map[string]interface{}{
+			"load_code":            0,
+			"active_code":          0,
+			"sub_code":             0,
+			"state_code":           10,
+			"cpu_usage_ns":         uint64(123456),
+			"memory_current_bytes": uint64(4096),
+		},
+		map[string]string{
+			"name":  "telegraf.service",
+			"state": "null",
+			"load":  "loaded",
+			"sub":   "running",
+			"scope": "system",
+		},
+	)
+}